@@ -0,0 +1,172 @@
+// Package chaos implements the sample app's fault-injection subsystem:
+// named profiles describing latency distributions, outage windows, and
+// error bursts, selectable per request and hot-reloadable from a YAML or
+// JSON config file.
+package chaos
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LatencyDistribution selects how a profile's injected latency is sampled.
+type LatencyDistribution string
+
+const (
+	LatencyConstant    LatencyDistribution = "constant"
+	LatencyUniform     LatencyDistribution = "uniform"
+	LatencyNormal      LatencyDistribution = "normal"
+	LatencyExponential LatencyDistribution = "exponential"
+)
+
+// Duration is a time.Duration that unmarshals from the human-readable form
+// accepted by time.ParseDuration (e.g. "5ms", "30s", "5m") in addition to a
+// plain integer number of nanoseconds, since neither yaml.v3 nor
+// encoding/json decode time.Duration from such strings on their own.
+type Duration time.Duration
+
+func (d *Duration) UnmarshalYAML(value *yaml.Node) error {
+	// yaml.v3 decodes any scalar, integers included, into a string, so the
+	// integer form has to be tried first.
+	var n int64
+	if err := value.Decode(&n); err == nil {
+		*d = Duration(n)
+		return nil
+	}
+
+	var s string
+	if err := value.Decode(&s); err != nil {
+		return fmt.Errorf("duration must be a string like \"30s\" or a number of nanoseconds: %w", err)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q: %w", s, err)
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		parsed, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q: %w", s, err)
+		}
+		*d = Duration(parsed)
+		return nil
+	}
+
+	var n int64
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("duration must be a string like \"30s\" or a number of nanoseconds: %w", err)
+	}
+	*d = Duration(n)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// LatencyConfig describes the delay injected before a response is written.
+type LatencyConfig struct {
+	Distribution LatencyDistribution `yaml:"distribution" json:"distribution"`
+	Mean         Duration            `yaml:"mean" json:"mean"`
+	StdDev       Duration            `yaml:"stddev" json:"stddev"`
+}
+
+// OutageWindow describes a recurring window during which requests fail at
+// FailRate, e.g. "fail 100% for 30s every 5m".
+type OutageWindow struct {
+	FailRate float64  `yaml:"failRate" json:"failRate"`
+	Duration Duration `yaml:"duration" json:"duration"`
+	Every    Duration `yaml:"every" json:"every"`
+}
+
+// ErrorBurst describes a token-bucket-limited stream of injected errors,
+// independent of the profile's baseline SuccessRate.
+type ErrorBurst struct {
+	RatePerSecond float64 `yaml:"ratePerSecond" json:"ratePerSecond"`
+	Burst         int     `yaml:"burst" json:"burst"`
+}
+
+// Profile bundles a set of fault-injection behaviors under a name that can
+// be selected per request.
+type Profile struct {
+	Name        string         `yaml:"name" json:"name"`
+	SuccessRate int            `yaml:"successRate" json:"successRate"`
+	Latency     *LatencyConfig `yaml:"latency,omitempty" json:"latency,omitempty"`
+	Outage      *OutageWindow  `yaml:"outage,omitempty" json:"outage,omitempty"`
+	ErrorBurst  *ErrorBurst    `yaml:"errorBurst,omitempty" json:"errorBurst,omitempty"`
+}
+
+// Config is the top-level shape of a -chaos-config file: a set of named
+// profiles, a default profile, and optional per-path overrides.
+type Config struct {
+	Default  string            `yaml:"default" json:"default"`
+	Profiles []Profile         `yaml:"profiles" json:"profiles"`
+	Paths    map[string]string `yaml:"paths,omitempty" json:"paths,omitempty"`
+}
+
+// Load reads and parses a Config from path, using YAML or JSON decoding
+// depending on the file extension (.json is decoded as JSON, anything else
+// as YAML).
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading chaos config %s: %w", path, err)
+	}
+
+	var cfg Config
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			return nil, fmt.Errorf("parsing chaos config %s as json: %w", path, err)
+		}
+	} else if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parsing chaos config %s as yaml: %w", path, err)
+	}
+
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("chaos config %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+func (c *Config) validate() error {
+	if len(c.Profiles) == 0 {
+		return fmt.Errorf("at least one profile is required")
+	}
+	found := false
+	for _, p := range c.Profiles {
+		if p.Name == c.Default {
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("default profile %q is not defined", c.Default)
+	}
+	return nil
+}
+
+// DefaultConfig returns a single-profile Config matching the app's historical
+// behavior: a constant 5ms delay and a configurable success rate, for use
+// when no -chaos-config is supplied.
+func DefaultConfig(successRate int) *Config {
+	return &Config{
+		Default: "default",
+		Profiles: []Profile{
+			{
+				Name:        "default",
+				SuccessRate: successRate,
+				Latency:     &LatencyConfig{Distribution: LatencyConstant, Mean: Duration(5 * time.Millisecond)},
+			},
+		},
+	}
+}
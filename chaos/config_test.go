@@ -0,0 +1,42 @@
+package chaos
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestDuration_UnmarshalYAML_HumanReadable(t *testing.T) {
+	var cfg LatencyConfig
+	if err := yaml.Unmarshal([]byte("distribution: normal\nmean: 5ms\nstddev: 30s\n"), &cfg); err != nil {
+		t.Fatalf("yaml.Unmarshal: %v", err)
+	}
+	if time.Duration(cfg.Mean) != 5*time.Millisecond {
+		t.Fatalf("Mean = %v, want 5ms", time.Duration(cfg.Mean))
+	}
+	if time.Duration(cfg.StdDev) != 30*time.Second {
+		t.Fatalf("StdDev = %v, want 30s", time.Duration(cfg.StdDev))
+	}
+}
+
+func TestDuration_UnmarshalJSON_HumanReadable(t *testing.T) {
+	var window OutageWindow
+	if err := json.Unmarshal([]byte(`{"failRate":1,"duration":"30s","every":"5m"}`), &window); err != nil {
+		t.Fatalf("json.Unmarshal: %v", err)
+	}
+	if time.Duration(window.Duration) != 30*time.Second {
+		t.Fatalf("Duration = %v, want 30s", time.Duration(window.Duration))
+	}
+	if time.Duration(window.Every) != 5*time.Minute {
+		t.Fatalf("Every = %v, want 5m", time.Duration(window.Every))
+	}
+}
+
+func TestDuration_UnmarshalRejectsGarbage(t *testing.T) {
+	var cfg LatencyConfig
+	if err := yaml.Unmarshal([]byte("mean: not-a-duration\n"), &cfg); err == nil {
+		t.Fatalf("expected an error unmarshaling an invalid duration string")
+	}
+}
@@ -0,0 +1,211 @@
+package chaos
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/time/rate"
+)
+
+const profileHeader = "X-Chaos-Profile"
+const profileQueryParam = "chaos_profile"
+
+var (
+	activeProfile = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "chaos_active_profile",
+			Help: "1 for the currently active chaos profile, 0 for all others",
+		},
+		[]string{"profile"},
+	)
+	injectedFaults = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "injected_faults_total",
+			Help: "Count of faults injected by the chaos subsystem, by fault type and profile",
+		},
+		[]string{"type", "profile"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(activeProfile)
+	prometheus.MustRegister(injectedFaults)
+}
+
+// Injector applies a Config's fault-injection behavior to incoming requests
+// and can be hot-reloaded without restarting the server.
+type Injector struct {
+	mu       sync.RWMutex
+	cfg      *Config
+	byName   map[string]*Profile
+	limiters map[string]*rate.Limiter
+	started  time.Time
+}
+
+// NewInjector creates an Injector running cfg.
+func NewInjector(cfg *Config) *Injector {
+	i := &Injector{started: time.Now()}
+	i.Reload(cfg)
+	return i
+}
+
+// Reload atomically swaps in a new Config, rebuilding per-profile rate
+// limiters and updating the active-profile gauge.
+func (i *Injector) Reload(cfg *Config) {
+	byName := make(map[string]*Profile, len(cfg.Profiles))
+	limiters := make(map[string]*rate.Limiter, len(cfg.Profiles))
+	for idx := range cfg.Profiles {
+		p := &cfg.Profiles[idx]
+		byName[p.Name] = p
+		if p.ErrorBurst != nil {
+			limiters[p.Name] = rate.NewLimiter(rate.Limit(p.ErrorBurst.RatePerSecond), p.ErrorBurst.Burst)
+		}
+	}
+
+	i.mu.Lock()
+	i.cfg = cfg
+	i.byName = byName
+	i.limiters = limiters
+	i.mu.Unlock()
+
+	activeProfile.Reset()
+	activeProfile.WithLabelValues(cfg.Default).Set(1)
+}
+
+// WatchReload reloads the Config from path every time the process receives
+// SIGHUP, until ctx is cancelled.
+func (i *Injector) WatchReload(ctx context.Context, path string) {
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+	go func() {
+		defer signal.Stop(sighup)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-sighup:
+				cfg, err := Load(path)
+				if err != nil {
+					log.Printf("chaos: failed to reload config from %s: %v", path, err)
+					continue
+				}
+				i.Reload(cfg)
+				log.Printf("chaos: reloaded config from %s", path)
+			}
+		}
+	}()
+}
+
+// ServeHTTP selects a profile for r, injects the configured faults, and
+// writes the resulting response.
+func (i *Injector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	profile := i.profileFor(r)
+
+	if profile.Latency != nil {
+		time.Sleep(sampleLatency(*profile.Latency))
+	}
+
+	if fault := i.fault(profile); fault != "" {
+		injectedFaults.WithLabelValues(fault, profile.Name).Inc()
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprint(w, "Fail\n")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "Hello World!\n")
+}
+
+// profileFor resolves the profile for r: an explicit X-Chaos-Profile header
+// or chaos_profile query parameter wins, then a per-path override, then the
+// config's default profile.
+func (i *Injector) profileFor(r *http.Request) *Profile {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	if name := r.Header.Get(profileHeader); name != "" {
+		if p, ok := i.byName[name]; ok {
+			return p
+		}
+	}
+	if name := r.URL.Query().Get(profileQueryParam); name != "" {
+		if p, ok := i.byName[name]; ok {
+			return p
+		}
+	}
+	if name, ok := i.cfg.Paths[r.URL.Path]; ok {
+		if p, ok := i.byName[name]; ok {
+			return p
+		}
+	}
+	return i.byName[i.cfg.Default]
+}
+
+// fault decides whether this request should fail and, if so, returns the
+// fault type that caused it. It returns "" when the request should succeed.
+func (i *Injector) fault(profile *Profile) string {
+	if profile.Outage != nil && i.inOutageWindow(*profile.Outage) {
+		if rand.Float64() < profile.Outage.FailRate {
+			return "outage"
+		}
+	}
+
+	i.mu.RLock()
+	limiter := i.limiters[profile.Name]
+	i.mu.RUnlock()
+	if limiter != nil && limiter.Allow() {
+		return "error_burst"
+	}
+
+	if rand.Intn(101) > profile.SuccessRate {
+		return "success_rate"
+	}
+	return ""
+}
+
+// inOutageWindow reports whether the current moment falls inside a
+// recurring outage window, measured relative to when the Injector started.
+func (i *Injector) inOutageWindow(w OutageWindow) bool {
+	every := time.Duration(w.Every)
+	if every <= 0 {
+		return false
+	}
+	elapsed := time.Since(i.started) % every
+	return elapsed < time.Duration(w.Duration)
+}
+
+// sampleLatency draws a single delay from the distribution described by cfg.
+func sampleLatency(cfg LatencyConfig) time.Duration {
+	mean := float64(time.Duration(cfg.Mean))
+	stddev := float64(time.Duration(cfg.StdDev))
+	switch cfg.Distribution {
+	case LatencyUniform:
+		d := mean + (rand.Float64()*2-1)*stddev
+		return clampNonNegative(d)
+	case LatencyNormal:
+		d := rand.NormFloat64()*stddev + mean
+		return clampNonNegative(d)
+	case LatencyExponential:
+		if mean <= 0 {
+			return 0
+		}
+		d := rand.ExpFloat64() * mean
+		return clampNonNegative(d)
+	default: // LatencyConstant and unset
+		return time.Duration(cfg.Mean)
+	}
+}
+
+func clampNonNegative(d float64) time.Duration {
+	return time.Duration(math.Max(0, d))
+}
@@ -0,0 +1,64 @@
+package chaos
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInOutageWindow(t *testing.T) {
+	i := &Injector{started: time.Now().Add(-90 * time.Second)}
+	window := OutageWindow{FailRate: 1, Duration: Duration(30 * time.Second), Every: Duration(60 * time.Second)}
+
+	// elapsed = 90s % 60s = 30s, which is at the edge of the 30s outage
+	// duration, so it must already have closed.
+	if i.inOutageWindow(window) {
+		t.Fatalf("inOutageWindow() = true at the boundary, want false")
+	}
+
+	i.started = time.Now().Add(-65 * time.Second)
+	// elapsed = 65s % 60s = 5s, inside the first 30s of the cycle.
+	if !i.inOutageWindow(window) {
+		t.Fatalf("inOutageWindow() = false 5s into the cycle, want true")
+	}
+}
+
+func TestInOutageWindow_DisabledWhenEveryIsZero(t *testing.T) {
+	i := &Injector{started: time.Now()}
+	if i.inOutageWindow(OutageWindow{FailRate: 1, Duration: Duration(time.Minute)}) {
+		t.Fatalf("inOutageWindow() = true with Every unset, want false")
+	}
+}
+
+func TestFault_ErrorBurstFiresWhenLimiterAllows(t *testing.T) {
+	cfg := &Config{
+		Default: "flaky",
+		Profiles: []Profile{
+			{
+				Name:        "flaky",
+				SuccessRate: 100, // would always succeed if the burst didn't fire
+				ErrorBurst:  &ErrorBurst{RatePerSecond: 1000, Burst: 1},
+			},
+		},
+	}
+	injector := NewInjector(cfg)
+	profile := injector.byName["flaky"]
+
+	if got := injector.fault(profile); got != "error_burst" {
+		t.Fatalf("fault() = %q, want \"error_burst\" when the limiter has budget", got)
+	}
+}
+
+func TestFault_NoErrorBurstWithoutConfig(t *testing.T) {
+	cfg := &Config{
+		Default: "baseline",
+		Profiles: []Profile{
+			{Name: "baseline", SuccessRate: 100},
+		},
+	}
+	injector := NewInjector(cfg)
+	profile := injector.byName["baseline"]
+
+	if got := injector.fault(profile); got != "" {
+		t.Fatalf("fault() = %q, want \"\" for a profile with no fault config and a 100%% success rate", got)
+	}
+}
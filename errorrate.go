@@ -0,0 +1,76 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// errorRateTracker computes the fraction of failed requests over a trailing
+// time window, for the request-error-rate health check.
+type errorRateTracker struct {
+	window time.Duration
+
+	mu     sync.Mutex
+	events []rateEvent
+}
+
+type rateEvent struct {
+	at     time.Time
+	failed bool
+}
+
+func newErrorRateTracker(window time.Duration) *errorRateTracker {
+	return &errorRateTracker{window: window}
+}
+
+// record adds the outcome of a single request to the tracker.
+func (t *errorRateTracker) record(failed bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.events = append(t.events, rateEvent{at: time.Now(), failed: failed})
+	t.prune()
+}
+
+// rate returns the fraction of failed requests observed within the trailing
+// window, or 0 if no requests have been recorded.
+func (t *errorRateTracker) rate() float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.prune()
+	if len(t.events) == 0 {
+		return 0
+	}
+	var failed int
+	for _, e := range t.events {
+		if e.failed {
+			failed++
+		}
+	}
+	return float64(failed) / float64(len(t.events))
+}
+
+// prune drops events older than the window. Callers must hold t.mu.
+//
+// Under sustained load, just re-slicing t.events[i:] would retain the whole
+// backing array for as long as any event in it survives, so once the slice's
+// capacity exceeds twice the number of surviving events plus 64, the
+// survivors are copied into a right-sized slice instead, bounding memory to
+// roughly one window's worth of events.
+func (t *errorRateTracker) prune() {
+	cutoff := time.Now().Add(-t.window)
+	i := 0
+	for i < len(t.events) && t.events[i].at.Before(cutoff) {
+		i++
+	}
+	if i == 0 {
+		return
+	}
+
+	remaining := t.events[i:]
+	if cap(t.events) > 2*len(remaining)+64 {
+		compacted := make([]rateEvent, len(remaining))
+		copy(compacted, remaining)
+		remaining = compacted
+	}
+	t.events = remaining
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestErrorRateTracker_Rate(t *testing.T) {
+	tr := newErrorRateTracker(time.Minute)
+
+	if got := tr.rate(); got != 0 {
+		t.Fatalf("expected rate 0 with no events, got %v", got)
+	}
+
+	tr.record(false)
+	tr.record(false)
+	tr.record(true)
+
+	if got, want := tr.rate(), 1.0/3.0; math.Abs(got-want) > 1e-9 {
+		t.Fatalf("rate() = %v, want %v", got, want)
+	}
+}
+
+func TestErrorRateTracker_PruneExpiresOldEvents(t *testing.T) {
+	tr := newErrorRateTracker(10 * time.Millisecond)
+
+	tr.record(true)
+	if got := tr.rate(); got != 1 {
+		t.Fatalf("rate() = %v, want 1 immediately after a single failure", got)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	tr.record(false)
+
+	if got := tr.rate(); got != 0 {
+		t.Fatalf("rate() = %v, want 0 once the earlier failure has aged out of the window", got)
+	}
+	if len(tr.events) != 1 {
+		t.Fatalf("len(events) = %d, want 1 after pruning the expired event", len(tr.events))
+	}
+}
+
+func TestErrorRateTracker_PruneCompactsBackingArray(t *testing.T) {
+	tr := newErrorRateTracker(5 * time.Millisecond)
+
+	for i := 0; i < 200; i++ {
+		tr.record(false)
+	}
+	grownCap := cap(tr.events)
+
+	time.Sleep(10 * time.Millisecond)
+	tr.record(false)
+
+	if cap(tr.events) >= grownCap {
+		t.Fatalf("expected prune to compact the backing array once most events expired, cap stayed at %d", cap(tr.events))
+	}
+}
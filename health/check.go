@@ -0,0 +1,23 @@
+// Package health implements a small pluggable health-check subsystem: named
+// checks run on independent intervals by a background scheduler, with their
+// latest status exposed over JSON, Prometheus gauges, and a simple
+// all-checks-passing readiness predicate.
+package health
+
+import "context"
+
+// Check is a single named health check. Execute is invoked periodically by a
+// Scheduler; a non-nil error marks the check as failing. The returned
+// details are opaque and surfaced as-is on the /health endpoint.
+type Check interface {
+	Name() string
+	Execute(ctx context.Context) (details any, err error)
+}
+
+// Status is the outcome of the most recent execution of a Check.
+type Status string
+
+const (
+	StatusHealthy   Status = "healthy"
+	StatusUnhealthy Status = "unhealthy"
+)
@@ -0,0 +1,98 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"runtime"
+	"time"
+)
+
+// GoroutineCountCheck fails when the number of live goroutines exceeds
+// threshold, as a cheap proxy for goroutine leaks.
+type GoroutineCountCheck struct {
+	threshold int
+}
+
+// NewGoroutineCountCheck creates a check that fails once runtime.NumGoroutine
+// exceeds threshold.
+func NewGoroutineCountCheck(threshold int) *GoroutineCountCheck {
+	return &GoroutineCountCheck{threshold: threshold}
+}
+
+func (c *GoroutineCountCheck) Name() string { return "goroutine-count" }
+
+func (c *GoroutineCountCheck) Execute(ctx context.Context) (any, error) {
+	n := runtime.NumGoroutine()
+	details := map[string]int{"count": n, "threshold": c.threshold}
+	if n > c.threshold {
+		return details, fmt.Errorf("goroutine count %d exceeds threshold %d", n, c.threshold)
+	}
+	return details, nil
+}
+
+// ErrorRateCheck fails when rate, typically the fraction of failed requests
+// observed over a trailing window, exceeds threshold. The rate itself is
+// supplied by the caller so this check stays independent of how requests are
+// counted.
+type ErrorRateCheck struct {
+	threshold float64
+	rate      func() float64
+}
+
+// NewErrorRateCheck creates a check that fails once rate() exceeds threshold.
+func NewErrorRateCheck(threshold float64, rate func() float64) *ErrorRateCheck {
+	return &ErrorRateCheck{threshold: threshold, rate: rate}
+}
+
+func (c *ErrorRateCheck) Name() string { return "request-error-rate" }
+
+func (c *ErrorRateCheck) Execute(ctx context.Context) (any, error) {
+	r := c.rate()
+	details := map[string]float64{"rate": r, "threshold": c.threshold}
+	if r > c.threshold {
+		return details, fmt.Errorf("request error rate %.4f exceeds threshold %.4f", r, c.threshold)
+	}
+	return details, nil
+}
+
+// HTTPDependencyCheck fails when a GET against url does not return within
+// timeout or comes back with a 5xx status, to demonstrate wiring a check for
+// a downstream dependency.
+type HTTPDependencyCheck struct {
+	name    string
+	url     string
+	client  *http.Client
+	timeout time.Duration
+}
+
+// NewHTTPDependencyCheck creates a check named name that probes url.
+func NewHTTPDependencyCheck(name, url string, timeout time.Duration) *HTTPDependencyCheck {
+	return &HTTPDependencyCheck{
+		name:    name,
+		url:     url,
+		client:  &http.Client{Timeout: timeout},
+		timeout: timeout,
+	}
+}
+
+func (c *HTTPDependencyCheck) Name() string { return c.name }
+
+func (c *HTTPDependencyCheck) Execute(ctx context.Context) (any, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("building request for %s: %w", c.url, err)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("probing %s: %w", c.url, err)
+	}
+	defer resp.Body.Close()
+
+	details := map[string]any{"url": c.url, "statusCode": resp.StatusCode}
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return details, fmt.Errorf("%s returned %d", c.url, resp.StatusCode)
+	}
+	return details, nil
+}
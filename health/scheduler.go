@@ -0,0 +1,157 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	checkStatus = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "health_check_status",
+			Help: "Current status of a health check, 1 if healthy and 0 otherwise",
+		},
+		[]string{"name"},
+	)
+	checkFailureTransitions = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "health_check_failure_transitions_total",
+			Help: "Number of times a health check transitioned from healthy to unhealthy",
+		},
+		[]string{"name"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(checkStatus)
+	prometheus.MustRegister(checkFailureTransitions)
+}
+
+// Result is the latest outcome of a registered Check, as surfaced over the
+// /health endpoint.
+type Result struct {
+	Status             Status    `json:"status"`
+	Details            any       `json:"details,omitempty"`
+	Error              string    `json:"error,omitempty"`
+	LastCheckedAt      time.Time `json:"lastCheckedAt"`
+	ContiguousFailures int       `json:"contiguousFailures"`
+}
+
+type entry struct {
+	check    Check
+	interval time.Duration
+
+	mu     sync.RWMutex
+	result Result
+}
+
+// Scheduler runs a set of registered Checks, each on its own interval, and
+// keeps track of their latest Result.
+type Scheduler struct {
+	mu      sync.RWMutex
+	entries map[string]*entry
+}
+
+// NewScheduler creates an empty Scheduler. Checks must be added with
+// Register before calling Start.
+func NewScheduler() *Scheduler {
+	return &Scheduler{entries: make(map[string]*entry)}
+}
+
+// Register adds check to the scheduler, to be run every interval once Start
+// is called. It panics if a check with the same name is already registered.
+func (s *Scheduler) Register(check Check, interval time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.entries[check.Name()]; exists {
+		panic("health: check " + check.Name() + " already registered")
+	}
+	s.entries[check.Name()] = &entry{check: check, interval: interval}
+}
+
+// Start runs every registered check once immediately and then on its own
+// interval, until ctx is cancelled.
+func (s *Scheduler) Start(ctx context.Context) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, e := range s.entries {
+		e := e
+		s.runOnce(ctx, e)
+		go func() {
+			ticker := time.NewTicker(e.interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					s.runOnce(ctx, e)
+				}
+			}
+		}()
+	}
+}
+
+func (s *Scheduler) runOnce(ctx context.Context, e *entry) {
+	details, err := e.check.Execute(ctx)
+
+	e.mu.Lock()
+	wasHealthy := !e.result.LastCheckedAt.IsZero() && e.result.Status != StatusUnhealthy
+	e.result.Details = details
+	e.result.LastCheckedAt = time.Now()
+	if err != nil {
+		e.result.Status = StatusUnhealthy
+		e.result.Error = err.Error()
+		e.result.ContiguousFailures++
+	} else {
+		e.result.Status = StatusHealthy
+		e.result.Error = ""
+		e.result.ContiguousFailures = 0
+	}
+	result := e.result
+	e.mu.Unlock()
+
+	name := e.check.Name()
+	if result.Status == StatusHealthy {
+		checkStatus.WithLabelValues(name).Set(1)
+	} else {
+		checkStatus.WithLabelValues(name).Set(0)
+		if wasHealthy {
+			checkFailureTransitions.WithLabelValues(name).Inc()
+		}
+	}
+}
+
+// Results returns the latest Result for every registered check, keyed by
+// check name.
+func (s *Scheduler) Results() map[string]Result {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make(map[string]Result, len(s.entries))
+	for name, e := range s.entries {
+		e.mu.RLock()
+		out[name] = e.result
+		e.mu.RUnlock()
+	}
+	return out
+}
+
+// Ready reports whether every registered check that has run at least once is
+// currently healthy. Checks that have not executed yet are treated as ready,
+// so readiness does not fail on startup before the first run completes.
+func (s *Scheduler) Ready() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, e := range s.entries {
+		e.mu.RLock()
+		unhealthy := e.result.Status == StatusUnhealthy
+		e.mu.RUnlock()
+		if unhealthy {
+			return false
+		}
+	}
+	return true
+}
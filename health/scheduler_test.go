@@ -0,0 +1,78 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+type fakeCheck struct {
+	name string
+	err  error
+}
+
+func (f *fakeCheck) Name() string { return f.name }
+
+func (f *fakeCheck) Execute(ctx context.Context) (any, error) { return nil, f.err }
+
+func TestRunOnce_FirstFailingRunIsNotCountedAsATransition(t *testing.T) {
+	s := NewScheduler()
+	check := &fakeCheck{name: "test-check-first-failure", err: errors.New("boom")}
+	s.Register(check, time.Hour)
+
+	s.runOnce(context.Background(), s.entries[check.Name()])
+
+	result := s.Results()[check.Name()]
+	if result.Status != StatusUnhealthy {
+		t.Fatalf("Status = %v, want unhealthy", result.Status)
+	}
+	if got := testutil.ToFloat64(checkFailureTransitions.WithLabelValues(check.Name())); got != 0 {
+		t.Fatalf("failure transitions = %v, want 0 for a check's first (failing) run", got)
+	}
+}
+
+func TestRunOnce_HealthyToUnhealthyIsCountedAsATransition(t *testing.T) {
+	s := NewScheduler()
+	check := &fakeCheck{name: "test-check-transition"}
+	s.Register(check, time.Hour)
+	e := s.entries[check.Name()]
+
+	s.runOnce(context.Background(), e) // healthy
+	check.err = errors.New("boom")
+	s.runOnce(context.Background(), e) // transitions to unhealthy
+
+	if got := testutil.ToFloat64(checkFailureTransitions.WithLabelValues(check.Name())); got != 1 {
+		t.Fatalf("failure transitions = %v, want 1", got)
+	}
+
+	// A further failing run is a repeat, not a new transition.
+	s.runOnce(context.Background(), e)
+	if got := testutil.ToFloat64(checkFailureTransitions.WithLabelValues(check.Name())); got != 1 {
+		t.Fatalf("failure transitions = %v, want 1 (unchanged by a repeated failure)", got)
+	}
+}
+
+func TestScheduler_ReadyReflectsCheckStatus(t *testing.T) {
+	s := NewScheduler()
+	check := &fakeCheck{name: "test-check-ready"}
+	s.Register(check, time.Hour)
+	e := s.entries[check.Name()]
+
+	if !s.Ready() {
+		t.Fatalf("Ready() = false before any run, want true")
+	}
+
+	s.runOnce(context.Background(), e)
+	if !s.Ready() {
+		t.Fatalf("Ready() = false after a healthy run, want true")
+	}
+
+	check.err = errors.New("boom")
+	s.runOnce(context.Background(), e)
+	if s.Ready() {
+		t.Fatalf("Ready() = true after a failing run, want false")
+	}
+}
@@ -2,112 +2,254 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"github.com/dvulpe/sampleapp-go/chaos"
+	"github.com/dvulpe/sampleapp-go/health"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"golang.org/x/sync/errgroup"
 	"log"
 	"math/rand"
 	"net/http"
 	"os"
 	"os/signal"
 	"strconv"
-	"sync"
+	"strings"
 	"sync/atomic"
 	"syscall"
 	"time"
 )
 
 var (
-	serverPort    int
-	metricsPort   int
-	stopTimeout   time.Duration
-	totalRequests = prometheus.NewCounterVec(
+	serverPort       int
+	metricsPort      int
+	stopTimeout      time.Duration
+	preshutdownDelay time.Duration
+	latencyBuckets   string
+	totalRequests    = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "http_requests_total",
 			Help: "Total http requests",
 		},
-		[]string{"code"},
+		[]string{"code", "method"},
 	)
-	durations = prometheus.NewHistogramVec(
+	requestErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "request_errors_total",
+			Help: "Total http requests that resulted in a 5xx response",
+		},
+		[]string{"code", "method"},
+	)
+	durations        *prometheus.HistogramVec
+	requestsInFlight = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "requests_in_flight",
+			Help: "Number of http requests currently being served",
+		},
+	)
+	requestSize = prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
-			Name:    "http_requests_duration",
-			Help:    "Duration of http requests",
-			Buckets: prometheus.ExponentialBuckets(0.001, 10, 5),
+			Name:    "request_size_bytes",
+			Help:    "Size of http requests",
+			Buckets: prometheus.ExponentialBuckets(64, 4, 8),
 		},
-		[]string{"code"},
+		[]string{"code", "method"},
 	)
-	healthy int32 = 0
+	responseSize = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "response_size_bytes",
+			Help:    "Size of http responses",
+			Buckets: prometheus.ExponentialBuckets(64, 4, 8),
+		},
+		[]string{"code", "method"},
+	)
+	errorRate *errorRateTracker
+	healthy   int32 = 0
+
+	healthCheckInterval    time.Duration
+	goroutineThreshold     int
+	errorRateThreshold     float64
+	errorRateWindow        time.Duration
+	dependencyCheckURL     string
+	dependencyCheckTimeout time.Duration
+
+	chaosConfigPath  string
+	chaosSuccessRate int
 )
 
 func init() {
 	prometheus.MustRegister(totalRequests)
-	prometheus.MustRegister(durations)
+	prometheus.MustRegister(requestErrors)
+	prometheus.MustRegister(requestsInFlight)
+	prometheus.MustRegister(requestSize)
+	prometheus.MustRegister(responseSize)
 	flag.IntVar(&metricsPort, "metrics-port", 8000, "Port to listen to for metrics")
 	flag.IntVar(&serverPort, "server-port", 8080, "Port to listen for http requests")
 	flag.DurationVar(&stopTimeout, "stop-timeout", 10*time.Second, "Server stop timeout")
+	flag.DurationVar(&preshutdownDelay, "preshutdown-delay", 5*time.Second, "Delay between failing readiness and shutting down the server, to give Kubernetes time to propagate endpoint changes")
+	flag.StringVar(&latencyBuckets, "latency-buckets", "0.001,0.01,0.1,1,10", "Comma separated list of histogram buckets (in seconds) used for the request duration metric")
+	flag.DurationVar(&healthCheckInterval, "health-check-interval", 15*time.Second, "How often built-in health checks are re-evaluated")
+	flag.IntVar(&goroutineThreshold, "health-goroutine-threshold", 1000, "Number of live goroutines above which the goroutine-count health check fails")
+	flag.Float64Var(&errorRateThreshold, "health-error-rate-threshold", 0.5, "Fraction of failed requests over the error-rate window above which the request-error-rate health check fails")
+	flag.DurationVar(&errorRateWindow, "health-error-rate-window", time.Minute, "Trailing window over which the request error rate is computed")
+	flag.StringVar(&dependencyCheckURL, "health-dependency-url", "", "URL of a downstream dependency to probe for health; the check is disabled if empty")
+	flag.DurationVar(&dependencyCheckTimeout, "health-dependency-timeout", 2*time.Second, "Timeout for the downstream dependency health check")
+	flag.StringVar(&chaosConfigPath, "chaos-config", "", "Path to a YAML or JSON chaos fault-injection config; reloaded on SIGHUP. If empty, a single default profile is used")
+	flag.IntVar(&chaosSuccessRate, "chaos-success-rate", 100, "Success rate (0-100) of the default chaos profile used when -chaos-config is not set")
 	rand.Seed(time.Now().UnixNano())
 }
 
+// loadChaosConfig reads the -chaos-config file if one was given, or else
+// builds a single-profile config matching the historical SUCCESS_RATE
+// behavior from -chaos-success-rate.
+func loadChaosConfig() (*chaos.Config, error) {
+	if chaosConfigPath == "" {
+		return chaos.DefaultConfig(chaosSuccessRate), nil
+	}
+	return chaos.Load(chaosConfigPath)
+}
+
+// parseLatencyBuckets turns the -latency-buckets flag value into the bucket
+// boundaries used by the http_requests_duration histogram.
+func parseLatencyBuckets(raw string) ([]float64, error) {
+	parts := strings.Split(raw, ",")
+	buckets := make([]float64, 0, len(parts))
+	for _, p := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid latency bucket %q: %w", p, err)
+		}
+		buckets = append(buckets, v)
+	}
+	return buckets, nil
+}
+
 func main() {
 	flag.Parse()
-	successRate, err := strconv.Atoi(os.Getenv("SUCCESS_RATE"))
+
+	chaosCfg, err := loadChaosConfig()
 	if err != nil {
-		log.Fatalf("could not parse succes rate: %v", err)
+		log.Fatalf("could not load chaos config: %v", err)
 	}
+	injector := chaos.NewInjector(chaosCfg)
 
-	var stopCh = make(chan int)
-	var wg = new(sync.WaitGroup)
-	wg.Add(2)
-	go startServer(createHttpServer(successRate), stopCh, wg)
-	go startServer(createMetricsServer(), stopCh, wg)
+	buckets, err := parseLatencyBuckets(latencyBuckets)
+	if err != nil {
+		log.Fatalf("could not parse latency buckets: %v", err)
+	}
+	durations = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "http_requests_duration",
+			Help:    "Duration of http requests",
+			Buckets: buckets,
+		},
+		[]string{"code", "method"},
+	)
+	prometheus.MustRegister(durations)
 
-	c := make(chan os.Signal, 2)
-	signal.Notify(c, syscall.SIGTERM, syscall.SIGINT, syscall.SIGKILL)
+	errorRate = newErrorRateTracker(errorRateWindow)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	if chaosConfigPath != "" {
+		injector.WatchReload(ctx, chaosConfigPath)
+	}
+
+	scheduler := health.NewScheduler()
+	scheduler.Register(lifecycleCheck{}, time.Second)
+	scheduler.Register(health.NewGoroutineCountCheck(goroutineThreshold), healthCheckInterval)
+	scheduler.Register(health.NewErrorRateCheck(errorRateThreshold, errorRate.rate), healthCheckInterval)
+	if dependencyCheckURL != "" {
+		scheduler.Register(health.NewHTTPDependencyCheck("downstream-dependency", dependencyCheckURL, dependencyCheckTimeout), healthCheckInterval)
+	}
+	scheduler.Start(ctx)
+
+	g, gctx := errgroup.WithContext(ctx)
+
+	// mainDrained is closed once the main http server has fully shut down,
+	// so the metrics server can keep serving /metrics and /liveness until
+	// scrapers see the final counters and orchestrators see readiness flip
+	// before liveness does.
+	mainDrained := make(chan struct{})
+	g.Go(func() error {
+		defer close(mainDrained)
+		return startServer(gctx, createHttpServer(injector), nil)
+	})
+	g.Go(func() error {
+		return startServer(gctx, createMetricsServer(scheduler), mainDrained)
+	})
 
-	go func() {
-		<-c
-		log.Println("About to stop server")
-		close(stopCh)
-	}()
 	atomic.StoreInt32(&healthy, 1)
-	wg.Wait()
+	if err := g.Wait(); err != nil {
+		log.Printf("server error: %v", err)
+		os.Exit(1)
+	}
 	log.Printf("All stopped.")
 }
 
-func startServer(srv *http.Server, stopCh chan int, wg *sync.WaitGroup) {
-	defer wg.Done()
+// startServer runs srv until ctx is cancelled, then drains it. If waitFor is
+// non-nil, shutdown is postponed until waitFor is closed instead of flipping
+// readiness and sleeping preshutdownDelay directly; this lets one server's
+// shutdown be sequenced after another's.
+func startServer(ctx context.Context, srv *http.Server, waitFor <-chan struct{}) error {
+	errCh := make(chan error, 1)
 	go func() {
 		log.Printf("Starting server on %v", srv.Addr)
-		if err := srv.ListenAndServe(); err != http.ErrServerClosed {
-			log.Fatalf("boom: %v", err)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			errCh <- err
+			return
 		}
+		errCh <- nil
 	}()
-	<-stopCh
-	srv.SetKeepAlivesEnabled(false)
-	atomic.StoreInt32(&healthy, 0)
-	time.Sleep(5 * time.Second) // give k8s some time to sync services
-	ctx, cancel := context.WithTimeout(context.Background(), stopTimeout)
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	if waitFor == nil {
+		srv.SetKeepAlivesEnabled(false)
+		atomic.StoreInt32(&healthy, 0)
+		time.Sleep(preshutdownDelay) // give k8s some time to sync services
+	} else {
+		<-waitFor
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), stopTimeout)
 	defer cancel()
 
 	log.Printf("Shutting down server on %v in %v\n", srv.Addr, stopTimeout)
-	if err := srv.Shutdown(ctx); err != nil {
+	if err := srv.Shutdown(shutdownCtx); err != nil {
 		log.Printf("Failed shutdown: %v", err)
-	} else {
-		log.Printf("Server %v stopped", srv.Addr)
+		return err
 	}
+	log.Printf("Server %v stopped", srv.Addr)
+	return nil
 }
 
-func createHttpServer(successRate int) *http.Server {
+func createHttpServer(injector *chaos.Injector) *http.Server {
 	mux := http.NewServeMux()
-	handler := promhttp.InstrumentHandlerDuration(
-		durations,
-		promhttp.InstrumentHandlerCounter(
-			totalRequests,
-			Handler(successRate),
+	handler := promhttp.InstrumentHandlerInFlight(
+		requestsInFlight,
+		promhttp.InstrumentHandlerDuration(
+			durations,
+			promhttp.InstrumentHandlerCounter(
+				totalRequests,
+				promhttp.InstrumentHandlerRequestSize(
+					requestSize,
+					promhttp.InstrumentHandlerResponseSize(
+						responseSize,
+						errorCountingHandler(injector),
+					),
+				),
+			),
 		),
 	)
-	mux.HandleFunc("/", handler)
+	mux.Handle("/", handler)
 	srv := &http.Server{
 		Handler:           mux,
 		Addr:              fmt.Sprintf(":%d", serverPort),
@@ -118,20 +260,48 @@ func createHttpServer(successRate int) *http.Server {
 	return srv
 }
 
-func Handler(successRate int) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		time.Sleep(5 * time.Millisecond) // don't be too fast
-		if rand.Intn(101) > successRate {
-			w.WriteHeader(http.StatusInternalServerError)
-			fmt.Fprint(w, "Fail\n")
-		} else {
-			w.WriteHeader(http.StatusOK)
-			fmt.Fprintf(w, "Hello World!\n")
+// statusRecorder captures the status code written by the wrapped handler so
+// it can be reported on request_errors_total.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// errorCountingHandler increments request_errors_total for every response
+// with a 5xx status code and feeds the request-error-rate health check.
+func errorCountingHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		failed := rec.status >= http.StatusInternalServerError
+		if failed {
+			requestErrors.WithLabelValues(strconv.Itoa(rec.status), r.Method).Inc()
 		}
+		errorRate.record(failed)
+	})
+}
+
+// lifecycleCheck reports the healthy flag as a health.Check, so the process's
+// own startup and shutdown state is listed on /health alongside the other
+// checks. The flag is 0 both before startup completes and once shutdown has
+// begun.
+type lifecycleCheck struct{}
+
+func (lifecycleCheck) Name() string { return "server-lifecycle" }
+
+func (lifecycleCheck) Execute(ctx context.Context) (any, error) {
+	if atomic.LoadInt32(&healthy) == 1 {
+		return nil, nil
 	}
+	return nil, fmt.Errorf("server is not serving traffic (starting up or shutting down)")
 }
 
-func createMetricsServer() *http.Server {
+func createMetricsServer(scheduler *health.Scheduler) *http.Server {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
 		promhttp.Handler().ServeHTTP(w, r)
@@ -141,14 +311,25 @@ func createMetricsServer() *http.Server {
 		fmt.Fprintf(w, "OK")
 	})
 	mux.HandleFunc("/readiness", func(w http.ResponseWriter, r *http.Request) {
-		if atomic.LoadInt32(&healthy) == 1 {
+		// The scheduler's own checks, including lifecycleCheck, stop being
+		// re-evaluated once ctx is cancelled for shutdown, so readiness is
+		// also gated directly on the healthy flag to make sure it flips as
+		// soon as shutdown begins rather than waiting on a stale cached
+		// result.
+		if atomic.LoadInt32(&healthy) == 1 && scheduler.Ready() {
 			w.WriteHeader(http.StatusOK)
 			fmt.Fprintf(w, "OK")
 		} else {
-			w.WriteHeader(http.StatusBadGateway)
+			w.WriteHeader(http.StatusServiceUnavailable)
 			fmt.Fprintf(w, "Unhealthy")
 		}
 	})
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(scheduler.Results()); err != nil {
+			log.Printf("failed to encode health status: %v", err)
+		}
+	})
 	return &http.Server{
 		Handler:      mux,
 		Addr:         fmt.Sprintf(":%d", metricsPort),